@@ -0,0 +1,186 @@
+package justinstall
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Backend fetches a resource identified by a URL. Backends are registered against a URL scheme
+// with RegisterBackend, so the download pipeline in util.go never needs to know how a given
+// registry entry's payload is actually transported.
+type Backend interface {
+	// Scheme returns this backend's canonical URL scheme, e.g. "http" or "file". It exists for
+	// logging and introspection; the scheme(s) a backend actually answers to are whatever it was
+	// registered under.
+	Scheme() string
+
+	// Open returns a reader for the resource at u. If rangeOffset is greater than zero, the
+	// backend should attempt to resume from that byte offset, but only if ifRange is non-empty
+	// and still matches the resource (an ETag or Last-Modified-style validator previously
+	// returned by this same backend); otherwise it must fall back to a full transfer from byte
+	// 0, since a stale or missing validator means the caller's partial bytes can no longer be
+	// trusted to belong to the same content. resumed reports whether it actually resumed (if
+	// false, the caller must discard anything already written past byte 0). size is the
+	// resource's total size if known, or 0 otherwise. validator is the resource's current
+	// validator, to be persisted by the caller and passed back as ifRange on the next attempt.
+	Open(ctx context.Context, u *url.URL, rangeOffset int64, ifRange string) (body io.ReadCloser, size int64, resumed bool, validator string, err error)
+}
+
+var backends = map[string]Backend{}
+
+// RegisterBackend makes b responsible for every URL whose scheme is scheme. Registering a second
+// backend for the same scheme replaces the first.
+func RegisterBackend(scheme string, b Backend) {
+	backends[scheme] = b
+}
+
+func init() {
+	h := &httpBackend{}
+	RegisterBackend("http", h)
+	RegisterBackend("https", h)
+
+	RegisterBackend("file", &fileBackend{})
+	RegisterBackend("s3", &s3Backend{})
+}
+
+// backendFor parses rawurl and looks up the Backend registered for its scheme.
+func backendFor(rawurl string) (Backend, *url.URL, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to parse the URL: %s", rawurl)
+	}
+
+	b, ok := backends[u.Scheme]
+	if !ok {
+		return nil, nil, fmt.Errorf("no download backend registered for scheme %q", u.Scheme)
+	}
+
+	return b, u, nil
+}
+
+// httpBackend fetches http(s):// URLs using customRequest, which applies the registered
+// RequestDecorators (Oracle cookies, AMD referer, Codeplex user agent, ...). It only sends a
+// Range request when handed a still-valid ifRange, so a resume is never attempted against
+// content that may have changed since the partial transfer was started.
+type httpBackend struct{}
+
+func (httpBackend) Scheme() string { return "http" }
+
+func (httpBackend) Open(ctx context.Context, u *url.URL, rangeOffset int64, ifRange string) (io.ReadCloser, int64, bool, string, error) {
+	request, client, err := customRequest(ctx, u.String())
+	if err != nil {
+		return nil, 0, false, "", err
+	}
+
+	wantResume := rangeOffset > 0 && ifRange != ""
+
+	if wantResume {
+		request.Header.Set("Range", fmt.Sprintf("bytes=%d-", rangeOffset))
+		request.Header.Set("If-Range", ifRange)
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, 0, false, "", err
+	}
+
+	resumed := wantResume && response.StatusCode == http.StatusPartialContent
+
+	if !resumed {
+		if response.StatusCode != http.StatusOK {
+			response.Body.Close()
+
+			if response.StatusCode >= 500 {
+				return nil, 0, false, "", fmt.Errorf("server error, wanted 200 but got %d", response.StatusCode)
+			}
+
+			return nil, 0, false, "", fmt.Errorf("unexpected HTTP response code, wanted 200 but got %d", response.StatusCode)
+		}
+	}
+
+	validator := response.Header.Get("ETag")
+	if validator == "" {
+		validator = response.Header.Get("Last-Modified")
+	}
+
+	size := response.ContentLength
+	if size < 0 {
+		size = 0
+	} else if resumed {
+		size += rangeOffset
+	}
+
+	return response.Body, size, resumed, validator, nil
+}
+
+// fileBackend fetches file:// URLs, for offline mirrors served off a local disk or network share.
+// A file://host/path URL is treated as a UNC-style path (\\host\path on Windows). Its validator is
+// the file's modification time, so a resume is skipped (in favor of a full re-read) if the file on
+// disk has been replaced since the partial transfer began.
+type fileBackend struct{}
+
+func (fileBackend) Scheme() string { return "file" }
+
+func (fileBackend) Open(ctx context.Context, u *url.URL, rangeOffset int64, ifRange string) (io.ReadCloser, int64, bool, string, error) {
+	path := u.Path
+	if u.Host != "" && u.Host != "localhost" {
+		path = filepath.FromSlash("//" + u.Host + u.Path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, false, "", err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, false, "", err
+	}
+
+	validator := info.ModTime().UTC().Format(time.RFC3339Nano)
+
+	resumed := false
+
+	if rangeOffset > 0 && ifRange != "" && ifRange == validator {
+		if _, err := f.Seek(rangeOffset, io.SeekStart); err != nil {
+			f.Close()
+			return nil, 0, false, "", err
+		}
+
+		resumed = true
+	}
+
+	return f, info.Size(), resumed, validator, nil
+}
+
+// s3Backend fetches s3://bucket/key URLs from public, unauthenticated buckets by translating them
+// into a virtual-hosted-style HTTPS URL and delegating to httpBackend. A "region" query parameter
+// selects the bucket's region (s3://bucket/key?region=eu-west-1); it defaults to us-east-1.
+// Private buckets need SigV4-signed requests, which this backend does not implement.
+type s3Backend struct {
+	http httpBackend
+}
+
+func (s3Backend) Scheme() string { return "s3" }
+
+func (b s3Backend) Open(ctx context.Context, u *url.URL, rangeOffset int64, ifRange string) (io.ReadCloser, int64, bool, string, error) {
+	region := u.Query().Get("region")
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	httpsURL := &url.URL{
+		Scheme: "https",
+		Host:   fmt.Sprintf("%s.s3.%s.amazonaws.com", u.Host, region),
+		Path:   u.Path,
+	}
+
+	return b.http.Open(ctx, httpsURL, rangeOffset, ifRange)
+}
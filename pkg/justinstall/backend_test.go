@@ -0,0 +1,85 @@
+package justinstall
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestBackendForDispatchesByScheme(t *testing.T) {
+	cases := []struct {
+		name    string
+		rawurl  string
+		wantErr bool
+	}{
+		{name: "http", rawurl: "http://example.com/file.exe"},
+		{name: "https", rawurl: "https://example.com/file.exe"},
+		{name: "file", rawurl: "file:///tmp/file.exe"},
+		{name: "s3", rawurl: "s3://bucket/key"},
+		{name: "unregistered scheme", rawurl: "bittorrent://example.com/file.exe", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			b, u, err := backendFor(tc.rawurl)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("backendFor(%q) error = %v, wantErr %v", tc.rawurl, err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+
+			if u == nil {
+				t.Error("backendFor returned a nil URL")
+			}
+			if b == nil {
+				t.Error("backendFor returned a nil Backend")
+			}
+		})
+	}
+}
+
+func TestApplyRequestDecorators(t *testing.T) {
+	cases := []struct {
+		name       string
+		rawurl     string
+		wantHeader string
+		wantValue  string
+	}{
+		{name: "codeplex user agent", rawurl: "https://download-codeplex.sec.s-msft.com/package.exe", wantHeader: "User-Agent", wantValue: "chocolatey command line"},
+		{name: "amd referer", rawurl: "http://www2.ati.com/drivers/catalyst.exe", wantHeader: "Referer", wantValue: "http://support.amd.com/"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, tc.rawurl, nil)
+			if err != nil {
+				t.Fatalf("NewRequest: %v", err)
+			}
+
+			client := &http.Client{}
+
+			applyRequestDecorators(tc.rawurl, req, client)
+
+			if got := req.Header.Get(tc.wantHeader); got != tc.wantValue {
+				t.Errorf("applyRequestDecorators(%q) %s = %q, want %q", tc.rawurl, tc.wantHeader, got, tc.wantValue)
+			}
+		})
+	}
+}
+
+func TestApplyRequestDecoratorsSetsOracleCookieJarOnEveryRequest(t *testing.T) {
+	rawurl := "http://example.com/unrelated.exe"
+
+	req, err := http.NewRequest(http.MethodGet, rawurl, nil)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+
+	client := &http.Client{}
+
+	applyRequestDecorators(rawurl, req, client)
+
+	if client.Jar == nil {
+		t.Fatal("applyRequestDecorators left client.Jar nil for an unrelated URL, want the always-on Oracle decorator to set one")
+	}
+}
@@ -0,0 +1,76 @@
+package justinstall
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+)
+
+// RequestDecorator customizes an outgoing HTTP request (and, if needed, the client it's sent
+// through) for a specific vendor's download quirks. New vendor-specific hacks should be added
+// here, as data, instead of growing an if/else chain in customRequest.
+type RequestDecorator struct {
+	// Match reports whether this decorator applies to the given request URL.
+	Match func(rawurl string) bool
+
+	// Decorate mutates req, and optionally client, before the request is sent.
+	Decorate func(req *http.Request, client *http.Client)
+}
+
+var requestDecorators []RequestDecorator
+
+// RegisterRequestDecorator adds d to the set applied to every outgoing request made through
+// customRequest.
+func RegisterRequestDecorator(d RequestDecorator) {
+	requestDecorators = append(requestDecorators, d)
+}
+
+func init() {
+	// Codeplex
+	RegisterRequestDecorator(RequestDecorator{
+		Match: func(rawurl string) bool {
+			return strings.Contains(rawurl, "download-codeplex.sec.s-msft.com")
+		},
+		Decorate: func(req *http.Request, client *http.Client) {
+			req.Header.Set("User-Agent", "chocolatey command line")
+		},
+	})
+
+	// AMD Catalyst
+	RegisterRequestDecorator(RequestDecorator{
+		Match: func(rawurl string) bool {
+			return strings.Contains(rawurl, "ati.com")
+		},
+		Decorate: func(req *http.Request, client *http.Client) {
+			req.Header.Set("Referer", "http://support.amd.com/")
+		},
+	})
+
+	// JRE/JDK from java.oracle.com. Applied to every request, as it was before this became a
+	// registry: the cookie is scoped to Oracle's own domains by the jar, so attaching it to
+	// unrelated requests is harmless.
+	RegisterRequestDecorator(RequestDecorator{
+		Match: func(rawurl string) bool { return true },
+		Decorate: func(req *http.Request, client *http.Client) {
+			oracleURL, _ := url.Parse("http://download.oracle.com")
+			oracleEdeliveryURL, _ := url.Parse("https://edelivery.oracle.com")
+			oracleCookies := []*http.Cookie{{Name: "oraclelicense", Value: "accept-securebackup-cookie"}}
+
+			jar, _ := cookiejar.New(nil)
+			jar.SetCookies(oracleURL, oracleCookies)
+			jar.SetCookies(oracleEdeliveryURL, oracleCookies)
+
+			client.Jar = jar
+		},
+	})
+}
+
+// applyRequestDecorators runs every matching decorator against req/client, in registration order.
+func applyRequestDecorators(rawurl string, req *http.Request, client *http.Client) {
+	for _, d := range requestDecorators {
+		if d.Match(rawurl) {
+			d.Decorate(req, client)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package justinstall
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidatorSidecar(t *testing.T) {
+	tempDestinationPath := filepath.Join(t.TempDir(), "payload.bin.tmp")
+
+	if got := readValidator(tempDestinationPath); got != "" {
+		t.Fatalf("readValidator on a fresh path = %q, want empty", got)
+	}
+
+	if err := writeValidator(tempDestinationPath, "etag-1"); err != nil {
+		t.Fatalf("writeValidator: %v", err)
+	}
+
+	if got := readValidator(tempDestinationPath); got != "etag-1" {
+		t.Fatalf("readValidator = %q, want %q", got, "etag-1")
+	}
+
+	clearValidator(tempDestinationPath)
+
+	if got := readValidator(tempDestinationPath); got != "" {
+		t.Fatalf("readValidator after clearValidator = %q, want empty", got)
+	}
+}
+
+func TestFileBackendOpenResumesOnlyWithMatchingValidator(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "payload.bin")
+	if err := os.WriteFile(path, []byte("hello world"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	u := &url.URL{Scheme: "file", Path: path}
+	backend := fileBackend{}
+
+	_, _, _, validator, err := backend.Open(context.Background(), u, 0, "")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if validator == "" {
+		t.Fatal("validator is empty, want the file's modification time")
+	}
+
+	body, _, resumed, _, err := backend.Open(context.Background(), u, 6, validator)
+	if err != nil {
+		t.Fatalf("Open (matching validator): %v", err)
+	}
+	body.Close()
+	if !resumed {
+		t.Error("resumed = false, want true when ifRange matches the file's current validator")
+	}
+
+	body, _, resumed, _, err = backend.Open(context.Background(), u, 6, "a-stale-validator")
+	if err != nil {
+		t.Fatalf("Open (stale validator): %v", err)
+	}
+	body.Close()
+	if resumed {
+		t.Error("resumed = true, want false when ifRange no longer matches the file's validator")
+	}
+
+	body, _, resumed, _, err = backend.Open(context.Background(), u, 6, "")
+	if err != nil {
+		t.Fatalf("Open (no validator): %v", err)
+	}
+	body.Close()
+	if resumed {
+		t.Error("resumed = true, want false when no validator is available to check the resume against")
+	}
+}
@@ -0,0 +1,292 @@
+package justinstall
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ulikunitz/xz"
+)
+
+// maxExtractedBytes caps the total decompressed size of a single archive, maxExtractedEntries
+// caps its number of entries, so a crafted archive (a "zip bomb" such as 42.zip) can't exhaust
+// disk or inodes during extraction, and maxSymlinkTargetBytes caps how much of a symlink entry's
+// target we'll read into memory.
+const (
+	maxExtractedBytes     = 10 << 30 // 10 GiB
+	maxExtractedEntries   = 100000
+	maxSymlinkTargetBytes = 4096
+)
+
+// safeJoin joins extractTo with the archive-supplied name and guarantees the result is lexically
+// contained under extractTo, rejecting Zip-Slip-style entries (e.g. "../../etc/passwd" or an
+// absolute path) instead of silently writing outside the destination.
+func safeJoin(extractTo string, name string) (string, error) {
+	if filepath.IsAbs(name) {
+		return "", fmt.Errorf("archive entry %q is an absolute path", name)
+	}
+
+	destinationPath := filepath.Join(extractTo, name)
+
+	extractToClean := filepath.Clean(extractTo) + string(os.PathSeparator)
+	if !strings.HasPrefix(filepath.Clean(destinationPath)+string(os.PathSeparator), extractToClean) {
+		return "", fmt.Errorf("archive entry %q escapes destination %s", name, extractTo)
+	}
+
+	return destinationPath, nil
+}
+
+// safeSymlinkTarget guards against Zip-Slip-via-symlink: a symlink entry whose own name is safely
+// contained under extractTo (per safeJoin) but whose target points outside it, so that a later
+// entry written "through" the link escapes the destination anyway. It rejects absolute targets
+// outright and resolves relative ones against the symlink's own directory before requiring the
+// result to stay lexically under extractTo.
+func safeSymlinkTarget(extractTo string, name string, target string) error {
+	if filepath.IsAbs(target) {
+		return fmt.Errorf("symlink %q has an absolute target %q", name, target)
+	}
+
+	resolved := filepath.Join(extractTo, filepath.Dir(name), target)
+
+	extractToClean := filepath.Clean(extractTo) + string(os.PathSeparator)
+	if !strings.HasPrefix(filepath.Clean(resolved)+string(os.PathSeparator), extractToClean) {
+		return fmt.Errorf("symlink %q targets %q, which escapes destination %s", name, target, extractTo)
+	}
+
+	return nil
+}
+
+// extractZip extracts the zip archive at path into extractTo. Entries are rejected if they would
+// escape extractTo (Zip-Slip), symlinks and empty directories are recreated explicitly, and file
+// mode and modification time are preserved from the zip header. Extraction stops and returns the
+// first error encountered; a size/entry-count cap defends against zip bombs.
+func extractZip(path string, extractTo string) error {
+	if err := os.MkdirAll(extractTo, 0700); err != nil {
+		return err
+	}
+
+	zipReader, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer zipReader.Close()
+
+	if len(zipReader.File) > maxExtractedEntries {
+		return fmt.Errorf("archive %s has too many entries (%d > %d)", path, len(zipReader.File), maxExtractedEntries)
+	}
+
+	var extracted int64
+
+	for _, zipFile := range zipReader.File {
+		destinationPath, err := safeJoin(extractTo, zipFile.Name)
+		if err != nil {
+			return err
+		}
+
+		mode := zipFile.Mode()
+
+		switch {
+		case mode&os.ModeSymlink != 0:
+			if err := extractZipSymlink(zipFile, extractTo, destinationPath); err != nil {
+				return err
+			}
+		case zipFile.FileInfo().IsDir():
+			if err := os.MkdirAll(destinationPath, 0700); err != nil {
+				return err
+			}
+		default:
+			n, err := extractZipFile(zipFile, destinationPath, maxExtractedBytes-extracted)
+			if err != nil {
+				return err
+			}
+
+			extracted += n
+			if extracted > maxExtractedBytes {
+				return fmt.Errorf("archive %s exceeds the %d byte extraction cap", path, maxExtractedBytes)
+			}
+		}
+
+		if err := os.Chtimes(destinationPath, zipFile.Modified, zipFile.Modified); err != nil && mode&os.ModeSymlink == 0 {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func extractZipSymlink(zipFile *zip.File, extractTo string, destinationPath string) error {
+	source, err := zipFile.Open()
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	target, err := io.ReadAll(io.LimitReader(source, maxSymlinkTargetBytes+1))
+	if err != nil {
+		return err
+	}
+
+	if len(target) > maxSymlinkTargetBytes {
+		return fmt.Errorf("symlink target for %q exceeds %d bytes", zipFile.Name, maxSymlinkTargetBytes)
+	}
+
+	if err := safeSymlinkTarget(extractTo, zipFile.Name, string(target)); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(destinationPath), 0755); err != nil {
+		return err
+	}
+
+	os.Remove(destinationPath)
+
+	return os.Symlink(string(target), destinationPath)
+}
+
+func extractZipFile(zipFile *zip.File, destinationPath string, remainingBudget int64) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(destinationPath), 0755); err != nil {
+		return 0, err
+	}
+
+	dest, err := os.OpenFile(destinationPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, zipFile.Mode().Perm())
+	if err != nil {
+		return 0, err
+	}
+	defer dest.Close()
+
+	source, err := zipFile.Open()
+	if err != nil {
+		return 0, err
+	}
+	defer source.Close()
+
+	n, err := io.CopyN(dest, source, remainingBudget+1)
+	if err != nil && err != io.EOF {
+		return n, err
+	}
+
+	return n, nil
+}
+
+// extractTarGz extracts the gzip-compressed tar archive at path into extractTo.
+func extractTarGz(path string, extractTo string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	gzipReader, err := gzip.NewReader(file)
+	if err != nil {
+		return err
+	}
+	defer gzipReader.Close()
+
+	return extractTarStream(tar.NewReader(gzipReader), extractTo, path)
+}
+
+// extractTarXz extracts the xz-compressed tar archive at path into extractTo.
+func extractTarXz(path string, extractTo string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	xzReader, err := xz.NewReader(file)
+	if err != nil {
+		return err
+	}
+
+	return extractTarStream(tar.NewReader(xzReader), extractTo, path)
+}
+
+// extractTarStream extracts every entry of tarReader into extractTo, applying the same Zip-Slip
+// protection, symlink handling and size/entry-count cap as extractZip. path is used only to
+// produce readable error messages.
+func extractTarStream(tarReader *tar.Reader, extractTo string, path string) error {
+	if err := os.MkdirAll(extractTo, 0700); err != nil {
+		return err
+	}
+
+	var extracted, entries int64
+
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		entries++
+		if entries > maxExtractedEntries {
+			return fmt.Errorf("archive %s has too many entries (> %d)", path, maxExtractedEntries)
+		}
+
+		destinationPath, err := safeJoin(extractTo, header.Name)
+		if err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(destinationPath, os.FileMode(header.Mode)); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := safeSymlinkTarget(extractTo, header.Name, header.Linkname); err != nil {
+				return err
+			}
+
+			if err := os.MkdirAll(filepath.Dir(destinationPath), 0755); err != nil {
+				return err
+			}
+
+			os.Remove(destinationPath)
+
+			if err := os.Symlink(header.Linkname, destinationPath); err != nil {
+				return err
+			}
+
+			continue // symlinks carry no timestamp of their own worth preserving
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(destinationPath), 0755); err != nil {
+				return err
+			}
+
+			dest, err := os.OpenFile(destinationPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode))
+			if err != nil {
+				return err
+			}
+
+			n, err := io.CopyN(dest, tarReader, maxExtractedBytes-extracted+1)
+			dest.Close()
+
+			if err != nil && err != io.EOF {
+				return err
+			}
+
+			extracted += n
+			if extracted > maxExtractedBytes {
+				return fmt.Errorf("archive %s exceeds the %d byte extraction cap", path, maxExtractedBytes)
+			}
+		default:
+			// Skip anything else (devices, fifos, ...): just data, no directory structure to
+			// recreate.
+			continue
+		}
+
+		if err := os.Chtimes(destinationPath, header.ModTime, header.ModTime); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
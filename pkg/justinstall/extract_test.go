@@ -0,0 +1,131 @@
+package justinstall
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSafeJoin(t *testing.T) {
+	extractTo := filepath.Join(string(os.PathSeparator), "tmp", "just-install-extract")
+
+	cases := []struct {
+		name    string
+		entry   string
+		wantErr bool
+	}{
+		{name: "plain file", entry: "bin/tool.exe", wantErr: false},
+		{name: "nested dir", entry: "a/b/c/d.txt", wantErr: false},
+		{name: "parent traversal", entry: "../../etc/passwd", wantErr: true},
+		{name: "traversal inside a safe-looking prefix", entry: "bin/../../etc/passwd", wantErr: true},
+		{name: "absolute path", entry: "/etc/passwd", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := safeJoin(extractTo, tc.entry)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("safeJoin(%q, %q) error = %v, wantErr %v", extractTo, tc.entry, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestSafeSymlinkTarget(t *testing.T) {
+	extractTo := filepath.Join(string(os.PathSeparator), "tmp", "just-install-extract")
+
+	cases := []struct {
+		name    string
+		entry   string
+		target  string
+		wantErr bool
+	}{
+		{name: "sibling file", entry: "lib/libfoo.so", target: "libfoo.so.1", wantErr: false},
+		{name: "parent dir still inside", entry: "lib/sub/link", target: "../real", wantErr: false},
+		{name: "absolute target", entry: "evil", target: "/etc/passwd", wantErr: true},
+		{name: "relative target escapes", entry: "evil", target: "../../../etc", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := safeSymlinkTarget(extractTo, tc.entry, tc.target)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("safeSymlinkTarget(%q, %q, %q) error = %v, wantErr %v", extractTo, tc.entry, tc.target, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestExtractZipRejectsZipSlip(t *testing.T) {
+	var buf bytes.Buffer
+
+	w := zip.NewWriter(&buf)
+
+	f, err := w.Create("../../etc/passwd")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := f.Write([]byte("pwned")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	archive := filepath.Join(t.TempDir(), "evil.zip")
+	if err := os.WriteFile(archive, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	extractTo := filepath.Join(t.TempDir(), "out")
+
+	if err := extractZip(archive, extractTo); err == nil {
+		t.Fatal("extractZip on a Zip-Slip archive succeeded, want an error")
+	}
+
+	if _, err := os.Stat(filepath.Join(filepath.Dir(filepath.Dir(extractTo)), "etc", "passwd")); !os.IsNotExist(err) {
+		t.Fatal("Zip-Slip entry was written outside the destination")
+	}
+}
+
+func TestExtractZipRejectsSymlinkEscape(t *testing.T) {
+	var buf bytes.Buffer
+
+	w := zip.NewWriter(&buf)
+
+	linkHeader := &zip.FileHeader{Name: "evil"}
+	linkHeader.SetMode(os.ModeSymlink | 0777)
+
+	link, err := w.CreateHeader(linkHeader)
+	if err != nil {
+		t.Fatalf("CreateHeader: %v", err)
+	}
+	if _, err := link.Write([]byte("../../../etc")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	victim, err := w.Create("evil/passwd")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := victim.Write([]byte("pwned")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	archive := filepath.Join(t.TempDir(), "evil-symlink.zip")
+	if err := os.WriteFile(archive, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	extractTo := filepath.Join(t.TempDir(), "out")
+
+	if err := extractZip(archive, extractTo); err == nil {
+		t.Fatal("extractZip on a symlink-escape archive succeeded, want an error")
+	}
+}
@@ -0,0 +1,32 @@
+package justinstall
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestDownloadHonorsCancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	destinationPath := filepath.Join(t.TempDir(), "payload.bin")
+
+	err := download(ctx, []string{"file:///does-not-exist"}, destinationPath, nil, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("download with a cancelled context returned %v, want context.Canceled", err)
+	}
+}
+
+func TestDownloadOneWithRetryHonorsCancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tempDestinationPath := filepath.Join(t.TempDir(), "payload.bin.tmp")
+
+	err := downloadOneWithRetry(ctx, "file:///does-not-exist", tempDestinationPath)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("downloadOneWithRetry with a cancelled context returned %v, want context.Canceled", err)
+	}
+}
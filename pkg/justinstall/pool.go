@@ -0,0 +1,149 @@
+package justinstall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	pb "gopkg.in/cheggaaa/pb.v1"
+)
+
+// Job is a single package install to be run by a Pool, identified by Name for progress reporting
+// and logging.
+type Job struct {
+	Name string
+	Run  func(ctx context.Context, progress *pb.ProgressBar) error
+}
+
+// Result is the outcome of running a single Job.
+type Result struct {
+	Name         string
+	Err          error
+	RebootNeeded bool
+}
+
+// Pool installs a fleet of packages concurrently, up to Jobs at a time, rendering one named
+// progress bar per job. Bars for every job are shown from the start of Run, including those of
+// jobs still waiting for a free slot.
+type Pool struct {
+	// Jobs is the maximum number of installs running at once. Values less than 1 are treated as 1.
+	Jobs int
+}
+
+// NewPool returns a Pool that runs up to jobs installs concurrently.
+func NewPool(jobs int) *Pool {
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	return &Pool{Jobs: jobs}
+}
+
+// Run executes every job, at most p.Jobs at a time, and returns one Result per job in the same
+// order jobs was given. The download cache (see lockDestination) serializes jobs that happen to
+// fetch the same URL, so increasing Jobs is always safe. ctx is passed through to every Job.Run
+// and, if cancelled, stops jobs that haven't started yet from being launched.
+func (p *Pool) Run(ctx context.Context, jobs []Job) []Result {
+	bars := make([]*pb.ProgressBar, len(jobs))
+
+	for i, job := range jobs {
+		bar := pb.New(0)
+		bar.Prefix(job.Name + " ")
+		bar.ShowSpeed = true
+		bar.SetUnits(pb.U_BYTES)
+
+		bars[i] = bar
+	}
+
+	pool, err := pb.StartPool(bars...)
+	if err != nil {
+		// Progress rendering is best-effort: fall back to running without it rather than failing
+		// the whole fleet.
+		pool = nil
+	}
+
+	results := make([]Result, len(jobs))
+	limit := p.Jobs
+	if limit < 1 {
+		limit = 1
+	}
+
+	sem := make(chan struct{}, limit)
+
+	var wg sync.WaitGroup
+
+	for i, job := range jobs {
+		sem <- struct{}{}
+
+		if ctx.Err() != nil {
+			<-sem
+			results[i] = Result{Name: job.Name, Err: ctx.Err()}
+			continue
+		}
+
+		wg.Add(1)
+
+		go func(i int, job Job) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			results[i] = runJob(ctx, job, bars[i])
+		}(i, job)
+	}
+
+	wg.Wait()
+
+	if pool != nil {
+		pool.Stop()
+	}
+
+	return results
+}
+
+// runJob runs a single job, translating ErrRebootRequired into Result.RebootNeeded instead of a
+// failure.
+func runJob(ctx context.Context, job Job, bar *pb.ProgressBar) Result {
+	err := job.Run(ctx, bar)
+
+	result := Result{Name: job.Name}
+
+	if errors.Is(err, ErrRebootRequired) {
+		result.RebootNeeded = true
+	} else {
+		result.Err = err
+	}
+
+	return result
+}
+
+// Summarize rolls a fleet of Results up into a single error combining the failures, if any, and
+// logs a note if one or more installs need a reboot to complete. A nil return means every job in
+// results succeeded (possibly with a pending reboot).
+func Summarize(results []Result) error {
+	var failed []string
+
+	rebootNeeded := false
+
+	for _, result := range results {
+		if result.Err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %s", result.Name, result.Err))
+		}
+
+		if result.RebootNeeded {
+			rebootNeeded = true
+		}
+	}
+
+	if rebootNeeded {
+		log.Println("One or more packages need a reboot to complete installation.")
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%d package(s) failed to install:\n%s", len(failed), strings.Join(failed, "\n"))
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package justinstall
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	pb "gopkg.in/cheggaaa/pb.v1"
+)
+
+func TestPoolRunIsolatesFailuresAndPreservesOrder(t *testing.T) {
+	boom := errors.New("boom")
+
+	jobs := []Job{
+		{Name: "a", Run: func(ctx context.Context, bar *pb.ProgressBar) error { return nil }},
+		{Name: "b", Run: func(ctx context.Context, bar *pb.ProgressBar) error { return boom }},
+		{Name: "c", Run: func(ctx context.Context, bar *pb.ProgressBar) error { return ErrRebootRequired }},
+	}
+
+	results := NewPool(2).Run(context.Background(), jobs)
+
+	if len(results) != len(jobs) {
+		t.Fatalf("got %d results, want %d", len(results), len(jobs))
+	}
+
+	if got := results[0]; got.Name != "a" || got.Err != nil || got.RebootNeeded {
+		t.Errorf("job a: got %+v, want {Name: a, Err: nil, RebootNeeded: false}", got)
+	}
+
+	// job b's failure must be confined to its own Result, not abort the whole Run (that was the
+	// point of the worker pool: one flaky install can't take down the rest of the fleet).
+	if got := results[1]; got.Name != "b" || !errors.Is(got.Err, boom) {
+		t.Errorf("job b: got %+v, want Err wrapping %v", got, boom)
+	}
+
+	if got := results[2]; got.Name != "c" || got.Err != nil || !got.RebootNeeded {
+		t.Errorf("job c: got %+v, want {Name: c, Err: nil, RebootNeeded: true}", got)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	cases := []struct {
+		name      string
+		results   []Result
+		wantError bool
+	}{
+		{
+			name:      "all succeed",
+			results:   []Result{{Name: "a"}, {Name: "b"}},
+			wantError: false,
+		},
+		{
+			name:      "reboot needed but no failures",
+			results:   []Result{{Name: "a", RebootNeeded: true}, {Name: "b"}},
+			wantError: false,
+		},
+		{
+			name:      "one failure",
+			results:   []Result{{Name: "a"}, {Name: "b", Err: errors.New("boom")}},
+			wantError: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Summarize(tc.results)
+			if (err != nil) != tc.wantError {
+				t.Errorf("Summarize(%+v) = %v, want error: %v", tc.results, err, tc.wantError)
+			}
+		})
+	}
+}
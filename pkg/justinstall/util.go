@@ -1,20 +1,25 @@
 package justinstall
 
 import (
-	"archive/zip"
 	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"hash"
 	"hash/crc32"
 	"io"
 	"log"
 	"net/http"
-	"net/http/cookiejar"
 	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"text/template"
 	"time"
@@ -24,6 +29,93 @@ import (
 	pb "gopkg.in/cheggaaa/pb.v1"
 )
 
+// Checksum describes the expected digest of a downloaded file, as found in a registry entry.
+// Algo is one of "sha256", "sha1" or "md5".
+//
+// This package only implements the verification primitive (see WithChecksum, verifyChecksum);
+// decoding a Checksum out of a registry entry's `checksum: {algo, value}` fields and passing it to
+// Fetcher.Download is the registry loader's job, and lives outside this package.
+type Checksum struct {
+	Algo  string `json:"algo"`
+	Value string `json:"value"`
+}
+
+// Signature describes an optional detached signature accompanying a downloaded file, as found in
+// a registry entry. Type is one of "gpg", "minisign" or "cosign".
+//
+// As with Checksum, this package only implements verification (see WithSignature,
+// verifySignature); wiring a registry entry's `signature: {type, key, url}` fields through to
+// Fetcher.Download is the registry loader's job.
+type Signature struct {
+	Type string `json:"type"`
+	Key  string `json:"key"`
+	URL  string `json:"url"`
+}
+
+// verifyChecksum hashes the file at path with the algorithm named by the checksum and compares it
+// against the expected value, returning an error if they don't match.
+func verifyChecksum(path string, checksum *Checksum) error {
+	if checksum == nil {
+		return nil
+	}
+
+	var h hash.Hash
+
+	switch strings.ToLower(checksum.Algo) {
+	case "sha256":
+		h = sha256.New()
+	case "sha1":
+		h = sha1.New()
+	case "md5":
+		h = md5.New()
+	default:
+		return fmt.Errorf("unsupported checksum algorithm: %s", checksum.Algo)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+
+	actual := hex.EncodeToString(h.Sum(nil))
+	expected := strings.ToLower(checksum.Value)
+
+	if actual != expected {
+		return fmt.Errorf("checksum mismatch for %s: want %s, got %s", path, expected, actual)
+	}
+
+	return nil
+}
+
+// verifySignature verifies the detached signature of the file at path, downloading the signature
+// itself from signature.URL if necessary. GPG, minisign and cosign signatures are supported.
+func verifySignature(ctx context.Context, path string, signature *Signature) error {
+	if signature == nil {
+		return nil
+	}
+
+	sigPath, err := NewFetcher().Download(ctx, signature.URL)
+	if err != nil {
+		return fmt.Errorf("unable to download signature %s: %s", signature.URL, err)
+	}
+
+	switch strings.ToLower(signature.Type) {
+	case "gpg":
+		return system("gpg", "--verify", sigPath, path)
+	case "minisign":
+		return system("minisign", "-V", "-P", signature.Key, "-m", path, "-x", sigPath)
+	case "cosign":
+		return system("cosign", "verify-blob", "--key", signature.Key, "--signature", sigPath, path)
+	default:
+		return fmt.Errorf("unsupported signature type: %s", signature.Type)
+	}
+}
+
 // expandString expands any environment variable in the given string, with additional variables
 // coming from the given context.
 func expandString(s string, context map[string]string) string {
@@ -97,15 +189,21 @@ func system(args ...string) error {
 
 		// msiexec returns 3010 if install needs reboot later
 		log.Printf("Exit code 3010, needs reboot to complete install.")
-		return nil
+		return ErrRebootRequired
 	}
 
 	return nil
 }
 
+// ErrRebootRequired is returned by system (and, transitively, by anything that shells out to
+// msiexec) when the underlying command reported exit code 3010: the install succeeded but needs a
+// reboot to take effect. Callers that care only about success/failure should check for it with
+// errors.Is before treating it as a genuine failure.
+var ErrRebootRequired = errors.New("install requires a reboot to complete")
+
 // Convenience wrapper over download3 which passes an empty ("") `ext` parameter.
 func downloadAutoExt(rawurl string, force bool) string {
-	return downloadExt(rawurl, "", force)
+	return downloadExtVerified(rawurl, "", force, nil, nil)
 }
 
 // Downloads a file over HTTP(S) to a temporary location. The temporary file has a name derived
@@ -113,20 +211,29 @@ func downloadAutoExt(rawurl string, force bool) string {
 // is not the empty string, it will be appended to the destination file. The file is re-downloaded
 // only if the temporary file is missing or `force` is true.
 func downloadExt(rawurl string, ext string, force bool) string {
-	u, err := url.Parse(rawurl)
-	if err != nil {
-		log.Fatalf("Unable to parse the URL: %s", rawurl)
-	}
+	return downloadExtVerified(rawurl, ext, force, nil, nil)
+}
 
-	var base string
+// downloadExtVerified behaves like downloadExt but additionally requires the downloaded file to
+// match the given checksum and signature, when provided by the registry entry.
+func downloadExtVerified(rawurl string, ext string, force bool, checksum *Checksum, signature *Signature) string {
+	return downloadExtMirrored([]string{rawurl}, ext, force, checksum, signature)
+}
 
-	if ext != "" {
-		base = crc32s(rawurl) + ext
-	} else {
-		base = crc32s(rawurl) + filepath.Ext(u.Path)
+// downloadExtMirrored behaves like downloadExtVerified but additionally accepts a list of mirror
+// URLs that are tried in order if the primary URL (rawurls[0]) fails.
+//
+// This and the other download* helpers above are thin wrappers, kept for existing call sites, that
+// exit the process via log.Fatalf on error; new code should use Fetcher.Download directly so it can
+// observe errors and cancel via a context.Context.
+func downloadExtMirrored(rawurls []string, ext string, force bool, checksum *Checksum, signature *Signature) string {
+	path, err := NewFetcher().Download(context.Background(), rawurls[0],
+		WithExt(ext), WithForce(force), WithChecksum(checksum), WithSignature(signature), WithMirrors(rawurls[1:]))
+	if err != nil {
+		log.Fatalf("%s", err)
 	}
 
-	return downloadTemp(rawurl, base, force)
+	return path
 }
 
 // Computes and returns the CRC32 of a string as an HEX string.
@@ -137,52 +244,341 @@ func crc32s(s string) string {
 	return fmt.Sprintf("%X", crc32.Sum32())
 }
 
+// Option configures a Fetcher.Download call.
+type Option func(*downloadConfig)
+
+type downloadConfig struct {
+	ext       string
+	force     bool
+	checksum  *Checksum
+	signature *Signature
+	mirrors   []string
+}
+
+// WithExt appends ext to the downloaded file's name instead of deriving it from the URL path.
+func WithExt(ext string) Option {
+	return func(c *downloadConfig) { c.ext = ext }
+}
+
+// WithForce re-downloads the file even if a cached copy already exists.
+func WithForce(force bool) Option {
+	return func(c *downloadConfig) { c.force = force }
+}
+
+// WithChecksum requires the downloaded file to match the given checksum.
+func WithChecksum(checksum *Checksum) Option {
+	return func(c *downloadConfig) { c.checksum = checksum }
+}
+
+// WithSignature requires the downloaded file to pass verification against the given signature.
+func WithSignature(signature *Signature) Option {
+	return func(c *downloadConfig) { c.signature = signature }
+}
+
+// WithMirrors tries each of the given URLs, in order, if the primary URL fails.
+func WithMirrors(mirrors []string) Option {
+	return func(c *downloadConfig) { c.mirrors = mirrors }
+}
+
+// Fetcher downloads registry payloads to the machine's temporary directory. The zero value is
+// ready to use.
+type Fetcher struct{}
+
+// NewFetcher returns a ready-to-use Fetcher.
+func NewFetcher() *Fetcher {
+	return &Fetcher{}
+}
+
+// Download fetches rawurl to the machine's temporary directory and returns the path of the
+// downloaded file, honoring ctx for cancellation and deadlines throughout the whole operation
+// (including any mirror and retry attempts). Unlike the legacy download* helpers, it never calls
+// log.Fatalf: all failures, including ctx.Err(), are returned to the caller.
+func (f *Fetcher) Download(ctx context.Context, rawurl string, opts ...Option) (string, error) {
+	var cfg downloadConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return "", fmt.Errorf("unable to parse the URL: %s", rawurl)
+	}
+
+	var base string
+
+	if cfg.ext != "" {
+		base = crc32s(rawurl) + cfg.ext
+	} else {
+		base = crc32s(rawurl) + filepath.Ext(u.Path)
+	}
+
+	destinationPath := filepath.Join(tempPath, base)
+	rawurls := append([]string{rawurl}, cfg.mirrors...)
+
+	if err := maybeDownload(ctx, rawurls, destinationPath, cfg.force, cfg.checksum, cfg.signature); err != nil {
+		return "", err
+	}
+
+	return destinationPath, nil
+}
+
 // downloadTemp downloads a file to the machine's temporary directory.
-func downloadTemp(rawurl string, filename string, force bool) string {
+func downloadTemp(rawurls []string, filename string, force bool, checksum *Checksum, signature *Signature) string {
 	ret := filepath.Join(tempPath, filename)
 
-	maybeDownload(rawurl, ret, force)
+	if err := maybeDownload(context.Background(), rawurls, ret, force, checksum, signature); err != nil {
+		log.Fatalf("%s", err)
+	}
 
 	return ret
 }
 
 // maybeDownload is a wrapper for download that doesn't re-download an existing file unless
-// forced.
-func maybeDownload(rawurl string, destinationPath string, force bool) {
+// forced. A cached file whose digest no longer matches the expected checksum is treated as
+// missing and re-downloaded.
+func maybeDownload(ctx context.Context, rawurls []string, destinationPath string, force bool, checksum *Checksum, signature *Signature) error {
+	unlock := lockDestination(destinationPath)
+	defer unlock()
+
+	if dry.FileExists(destinationPath) && !force && checksum != nil {
+		if err := verifyChecksum(destinationPath, checksum); err != nil {
+			log.Printf("Cached file %s failed verification, re-downloading: %s", destinationPath, err)
+			force = true
+		}
+	}
+
 	if !dry.FileExists(destinationPath) || force {
-		download(rawurl, destinationPath)
+		return download(ctx, rawurls, destinationPath, checksum, signature)
 	}
+
+	return nil
+}
+
+// downloadLocks holds one *sync.Mutex per destination path currently known to the cache, so that
+// concurrent installs (see Pool) racing to fetch the same URL serialize onto a single download
+// instead of clobbering each other's temp file.
+var downloadLocks sync.Map
+
+// lockDestination acquires the lock guarding destinationPath and returns a function that releases
+// it.
+func lockDestination(destinationPath string) func() {
+	value, _ := downloadLocks.LoadOrStore(destinationPath, &sync.Mutex{})
+	mu := value.(*sync.Mutex)
+	mu.Lock()
+
+	return mu.Unlock
 }
 
-// download a file with the HTTP/HTTPS protocol showing a progress bar. The destination file is
-// always overwritten.
-func download(rawurl string, destinationPath string) {
+// downloadMaxRetries is the number of attempts made against a single URL before falling through
+// to the next mirror.
+const downloadMaxRetries = 5
+
+// downloadInitialBackoff is the delay before the first retry. Each subsequent retry doubles it.
+const downloadInitialBackoff = time.Second
+
+// download fetches a file through the Backend registered for each URL's scheme (see backendFor),
+// showing a progress bar. rawurls is tried in order, falling through to the next entry (a mirror)
+// if one is exhausted. Every failed attempt is retried with exponential backoff, whatever the
+// cause: network errors and 5xx responses, but also 4xx responses and missing files. If a
+// partially downloaded ".tmp" file already exists, the transfer resumes from its end (an HTTP
+// Range request for http(s)) instead of starting over, but only once the backend confirms (via the validator sidecar written by downloadOnce)
+// that the remote content hasn't changed since the partial transfer began; otherwise it starts
+// over. The destination file is only written once the transfer has completed successfully. If
+// checksum or signature are non-nil, the downloaded file must pass verification before it is
+// renamed into place. ctx is checked between attempts so a cancellation stops further retries and
+// mirror fallbacks.
+func download(ctx context.Context, rawurls []string, destinationPath string, checksum *Checksum, signature *Signature) error {
 	tempDestinationPath := destinationPath + ".tmp"
 
-	destination, err := os.Create(tempDestinationPath)
+	var lastErr error
+
+	for i, rawurl := range rawurls {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if i > 0 {
+			// Falling through to a mirror. The previous URL's validator means nothing to a
+			// different host, so drop it rather than risk an If-Range match against the wrong
+			// server's ETag semantics.
+			clearValidator(tempDestinationPath)
+
+			if checksum == nil {
+				// Without a checksum to catch a mismatch, resuming a partial transfer that was
+				// started against a different URL risks silently splicing together bytes from two
+				// different files, so start the mirror from scratch instead.
+				if err := os.Truncate(tempDestinationPath, 0); err != nil && !os.IsNotExist(err) {
+					return err
+				}
+			}
+		}
+
+		if lastErr = downloadOneWithRetry(ctx, rawurl, tempDestinationPath); lastErr == nil {
+			break
+		}
+
+		log.Printf("Giving up on %s: %s", rawurl, lastErr)
+	}
+
+	if lastErr != nil {
+		return fmt.Errorf("unable to download %s: %s", destinationPath, lastErr)
+	}
+
+	if err := verifyChecksum(tempDestinationPath, checksum); err != nil {
+		return err
+	}
+
+	if err := verifySignature(ctx, tempDestinationPath, signature); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tempDestinationPath, destinationPath); err != nil {
+		return fmt.Errorf("cannot rename %s to %s (%s)", tempDestinationPath, destinationPath, err)
+	}
+
+	clearValidator(tempDestinationPath)
+
+	return nil
+}
+
+// downloadOneWithRetry downloads a single URL to tempDestinationPath, retrying any failed attempt
+// with exponential backoff, up to downloadMaxRetries attempts. It does not tell transient failures
+// (network errors, 5xx responses) from permanent ones: a 404 is retried like a 503. ctx
+// cancellation aborts the retry loop immediately.
+func downloadOneWithRetry(ctx context.Context, rawurl string, tempDestinationPath string) error {
+	backoff := downloadInitialBackoff
+
+	var err error
+
+	for attempt := 1; attempt <= downloadMaxRetries; attempt++ {
+		if err = downloadOnce(ctx, rawurl, tempDestinationPath); err == nil {
+			return nil
+		}
+
+		if attempt == downloadMaxRetries {
+			break
+		}
+
+		log.Printf("Attempt %d/%d failed for %s: %s (retrying in %s)", attempt, downloadMaxRetries, rawurl, err, backoff)
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		backoff *= 2
+	}
+
+	return err
+}
+
+// validatorSidecarPath returns the path of the file that download and downloadOnce use to persist
+// the validator (an ETag or Last-Modified, see Backend.Open) that tempDestinationPath's bytes were
+// downloaded against, so a later resume attempt can be checked against the backend's current
+// validator before trusting those bytes.
+func validatorSidecarPath(tempDestinationPath string) string {
+	return tempDestinationPath + ".validator"
+}
+
+// readValidator returns the validator previously persisted for tempDestinationPath, or "" if none
+// was recorded (including if tempDestinationPath predates this mechanism entirely) -- either way,
+// an empty ifRange tells Backend.Open it must not resume.
+func readValidator(tempDestinationPath string) string {
+	data, err := os.ReadFile(validatorSidecarPath(tempDestinationPath))
+	if err != nil {
+		return ""
+	}
+
+	return string(data)
+}
+
+// writeValidator persists validator for tempDestinationPath, or clears any previously persisted
+// validator if validator is empty.
+func writeValidator(tempDestinationPath string, validator string) error {
+	if validator == "" {
+		clearValidator(tempDestinationPath)
+		return nil
+	}
+
+	return os.WriteFile(validatorSidecarPath(tempDestinationPath), []byte(validator), 0600)
+}
+
+// clearValidator removes any validator persisted for tempDestinationPath. It is a no-op if none
+// exists.
+func clearValidator(tempDestinationPath string) {
+	os.Remove(validatorSidecarPath(tempDestinationPath))
+}
+
+// downloadOnce performs a single download attempt, resuming from the end of tempDestinationPath
+// (via an HTTP Range request for http(s)) if it already exists and the backend's current validator still
+// matches the one recorded (see writeValidator) when those bytes were downloaded.
+func downloadOnce(ctx context.Context, rawurl string, tempDestinationPath string) error {
+	var resumeFrom int64
+
+	if info, err := os.Stat(tempDestinationPath); err == nil {
+		resumeFrom = info.Size()
+	}
+
+	var ifRange string
+	if resumeFrom > 0 {
+		ifRange = readValidator(tempDestinationPath)
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY
+	if resumeFrom > 0 {
+		flags |= os.O_APPEND
+	} else {
+		flags |= os.O_TRUNC
+	}
+
+	destination, err := os.OpenFile(tempDestinationPath, flags, 0666)
 	if err != nil {
-		log.Fatalf("Unable to open the destination file: %s", tempDestinationPath)
+		return fmt.Errorf("unable to open the destination file: %s", tempDestinationPath)
 	}
 	defer destination.Close()
 
-	response, err := CustomGet(rawurl)
+	backend, u, err := backendFor(rawurl)
 	if err != nil {
-		log.Fatalf("Unable to open a connection to %s", rawurl)
+		return err
 	}
-	defer response.Body.Close()
 
-	if response.StatusCode != http.StatusOK {
-		log.Fatalf("Unexpected HTTP response code. Wanted 200 but got %d", response.StatusCode)
+	body, size, resumed, validator, err := backend.Open(ctx, u, resumeFrom, ifRange)
+	if err != nil {
+		return fmt.Errorf("unable to open %s: %s", rawurl, err)
+	}
+	defer body.Close()
+
+	// The backend didn't resume (no validator to trust, a stale one, or we didn't ask): start
+	// over from scratch.
+	if !resumed && resumeFrom > 0 {
+		if err := destination.Truncate(0); err != nil {
+			return err
+		}
+
+		if _, err := destination.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+	}
+
+	// Record the validator for this attempt before copying any bytes, so a process that dies
+	// mid-transfer leaves behind a validator matching what's actually on disk.
+	if err := writeValidator(tempDestinationPath, validator); err != nil {
+		return err
 	}
 
 	var progressBar *pb.ProgressBar
 
-	contentLength, err := strconv.Atoi(response.Header.Get("Content-Length"))
-	if err == nil {
-		progressBar = pb.New(int(contentLength))
+	if size > 0 {
+		progressBar = pb.New64(size)
 	} else {
 		progressBar = pb.New(0)
 	}
+
+	if resumed {
+		progressBar.Set(int(resumeFrom))
+	}
+
 	defer progressBar.Finish()
 
 	progressBar.ShowSpeed = true
@@ -192,93 +588,67 @@ func download(rawurl string, destinationPath string) {
 
 	writer := io.MultiWriter(destination, progressBar)
 
-	if _, err := io.Copy(writer, response.Body); err != nil {
-		log.Fatalf("Error downloading file: %s\n", err)
-	}
-
-	if err := destination.Close(); err != nil {
-		log.Fatalf("Cannot close destination file: %s\n", err)
+	if _, err := io.Copy(writer, body); err != nil {
+		return fmt.Errorf("error downloading file: %s", err)
 	}
 
-	if err := os.Rename(tempDestinationPath, destinationPath); err != nil {
-		log.Fatalf("Cannot rename %s to %s (%s)\n", tempDestinationPath, destinationPath, err)
-	}
+	return destination.Close()
 }
 
 func CustomGet(urlStr string, timeout ...time.Duration) (*http.Response, error) {
-	// FIXME(lvillani): Adding a variadic timeout argument allows us to keep backward compatibility
-	// with users of this API. This should be taken into account when designing the new fetch API.
+	return CustomGetContext(context.Background(), urlStr, timeout...)
+}
 
-	request, err := http.NewRequest("GET", urlStr, nil)
+// CustomGetContext behaves like CustomGet but threads ctx through the request, so cancelling ctx
+// (or hitting its deadline) aborts the connection attempt and any in-flight transfer.
+func CustomGetContext(ctx context.Context, urlStr string, timeout ...time.Duration) (*http.Response, error) {
+	request, client, err := customRequest(ctx, urlStr, timeout...)
 	if err != nil {
 		return nil, err
 	}
 
-	// Codeplex
-	if strings.Contains(urlStr, "download-codeplex.sec.s-msft.com") {
-		request.Header.Set("User-Agent", "chocolatey command line")
-	}
-
-	// AMD Catalyst
-	if strings.Contains(urlStr, "ati.com") {
-		request.Header.Set("Referer", "http://support.amd.com/")
-	}
+	return client.Do(request)
+}
 
-	// JRE/JDK from java.oracle.com
-	oracleURL, _ := url.Parse("http://download.oracle.com")
-	oracleEdeliveryURL, _ := url.Parse("https://edelivery.oracle.com")
-	oracleCookies := []*http.Cookie{{Name: "oraclelicense", Value: "accept-securebackup-cookie"}}
+// CustomGetRange behaves like CustomGet but additionally asks the server to resume the transfer
+// starting at the given byte offset via an HTTP Range request. Servers that don't support range
+// requests will simply ignore the header and respond with the full body from the start.
+func CustomGetRange(urlStr string, offset int64, timeout ...time.Duration) (*http.Response, error) {
+	return CustomGetRangeContext(context.Background(), urlStr, offset, timeout...)
+}
 
-	jar, _ := cookiejar.New(nil)
-	jar.SetCookies(oracleURL, oracleCookies)
-	jar.SetCookies(oracleEdeliveryURL, oracleCookies)
+// CustomGetRangeContext behaves like CustomGetRange but threads ctx through the request.
+func CustomGetRangeContext(ctx context.Context, urlStr string, offset int64, timeout ...time.Duration) (*http.Response, error) {
+	request, client, err := customRequest(ctx, urlStr, timeout...)
+	if err != nil {
+		return nil, err
+	}
 
-	client := fetch.NewClient()
-	client.Jar = jar
-	if len(timeout) > 0 {
-		client.Timeout = timeout[0]
+	if offset > 0 {
+		request.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
 	}
 
 	return client.Do(request)
 }
 
-func extractZip(path string, extractTo string) error {
-	os.MkdirAll(extractTo, 0700)
+// customRequest builds the GET request and HTTP client shared by CustomGet and CustomGetRange,
+// applying the per-host quirks (Codeplex, AMD, Oracle) needed to fetch some vendor installers.
+func customRequest(ctx context.Context, urlStr string, timeout ...time.Duration) (*http.Request, *http.Client, error) {
+	// FIXME(lvillani): Adding a variadic timeout argument allows us to keep backward compatibility
+	// with users of this API. This should be taken into account when designing the new fetch API.
 
-	// Open the archive for reading
-	zipReader, err := zip.OpenReader(path)
+	request, err := http.NewRequestWithContext(ctx, "GET", urlStr, nil)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
-	defer zipReader.Close()
 
-	// Extract all entries in the archive
-	for _, zipFile := range zipReader.File {
-		destinationPath := filepath.Join(extractTo, zipFile.Name)
-
-		if zipFile.FileInfo().IsDir() {
-			os.MkdirAll(destinationPath, zipFile.Mode())
-		} else {
-			os.MkdirAll(filepath.Dir(destinationPath), 0777)
-			// Create destination file
-			dest, err := os.Create(destinationPath)
-			if err != nil {
-				return err
-			}
-
-			// Open input stream
-			source, err := zipFile.Open()
-			if err != nil {
-				dest.Close()
-				return err
-			}
-
-			// Extract file
-			io.Copy(dest, source)
-			dest.Close()
-			source.Close()
-		}
+	client := fetch.NewClient()
+	if len(timeout) > 0 {
+		client.Timeout = timeout[0]
 	}
 
-	return nil
+	applyRequestDecorators(urlStr, request, client)
+
+	return request, client, nil
 }
+
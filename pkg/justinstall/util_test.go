@@ -0,0 +1,50 @@
+package justinstall
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVerifyChecksum(t *testing.T) {
+	content := []byte("just-install")
+
+	path := filepath.Join(t.TempDir(), "payload.bin")
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	sum := sha256.Sum256(content)
+	validSHA256 := hex.EncodeToString(sum[:])
+
+	cases := []struct {
+		name      string
+		checksum  *Checksum
+		wantError bool
+	}{
+		{name: "nil checksum is a no-op", checksum: nil, wantError: false},
+		{name: "matching sha256", checksum: &Checksum{Algo: "sha256", Value: validSHA256}, wantError: false},
+		{name: "matching sha256 is case-insensitive", checksum: &Checksum{Algo: "SHA256", Value: validSHA256}, wantError: false},
+		{name: "mismatched digest", checksum: &Checksum{Algo: "sha256", Value: "deadbeef"}, wantError: true},
+		{name: "unsupported algorithm", checksum: &Checksum{Algo: "sha512", Value: validSHA256}, wantError: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := verifyChecksum(path, tc.checksum)
+			if (err != nil) != tc.wantError {
+				t.Errorf("verifyChecksum(%q, %+v) error = %v, wantError %v", path, tc.checksum, err, tc.wantError)
+			}
+		})
+	}
+}
+
+func TestVerifyChecksumMissingFile(t *testing.T) {
+	checksum := &Checksum{Algo: "sha256", Value: "deadbeef"}
+
+	if err := verifyChecksum(filepath.Join(t.TempDir(), "does-not-exist"), checksum); err == nil {
+		t.Fatal("verifyChecksum on a missing file succeeded, want an error")
+	}
+}